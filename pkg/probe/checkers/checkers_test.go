@@ -0,0 +1,153 @@
+package checkers_test
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/magneticio/vamp-health-probe/pkg/probe/checkers"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTCP(t *testing.T) {
+	Convey("Given a listening TCP server", t, func() {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		So(err, ShouldBeNil)
+		defer ln.Close()
+		go func() {
+			for {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+				conn.Close()
+			}
+		}()
+
+		Convey("TCP check against it passes", func() {
+			check := checkers.TCP(ln.Addr().String(), time.Second)
+			So(check(), ShouldBeNil)
+		})
+
+		Convey("TCP check against a closed port fails", func() {
+			closed, err := net.Listen("tcp", "127.0.0.1:0")
+			So(err, ShouldBeNil)
+			addr := closed.Addr().String()
+			closed.Close()
+
+			check := checkers.TCP(addr, time.Second)
+			So(check(), ShouldNotBeNil)
+		})
+	})
+}
+
+func TestHTTPChecker(t *testing.T) {
+	Convey("Given an HTTP server", t, func() {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/ok" {
+				w.Write([]byte("all good"))
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer srv.Close()
+
+		Convey("A 200 response passes the default status range", func() {
+			check := checkers.HTTP(srv.URL + "/ok")
+			So(check(), ShouldBeNil)
+		})
+
+		Convey("A 500 response fails", func() {
+			check := checkers.HTTP(srv.URL + "/fail")
+			So(check(), ShouldNotBeNil)
+		})
+
+		Convey("WithBodyContains rejects a response missing the substring", func() {
+			check := checkers.HTTP(srv.URL+"/ok", checkers.WithBodyContains("nope"))
+			So(check(), ShouldNotBeNil)
+		})
+	})
+}
+
+func TestExecChecker(t *testing.T) {
+	Convey("Exec passes for a command exiting 0", t, func() {
+		check := checkers.Exec("true")
+		So(check(), ShouldBeNil)
+	})
+
+	Convey("Exec fails for a command exiting non-zero", t, func() {
+		check := checkers.Exec("false")
+		So(check(), ShouldNotBeNil)
+	})
+}
+
+func TestUDPChecker(t *testing.T) {
+	Convey("Given a UDP listener that never replies", t, func() {
+		ln, err := net.ListenPacket("udp", "127.0.0.1:0")
+		So(err, ShouldBeNil)
+		defer ln.Close()
+		go func() {
+			buf := make([]byte, 1)
+			for {
+				if _, _, err := ln.ReadFrom(buf); err != nil {
+					return
+				}
+			}
+		}()
+
+		Convey("A read timeout with no reply is treated as success, matching Consul's UDP check", func() {
+			check := checkers.UDP(ln.LocalAddr().String(), 100*time.Millisecond)
+			So(check(), ShouldBeNil)
+		})
+	})
+
+	Convey("Given a UDP port nothing is listening on", t, func() {
+		ln, err := net.ListenPacket("udp", "127.0.0.1:0")
+		So(err, ShouldBeNil)
+		addr := ln.LocalAddr().String()
+		ln.Close()
+
+		Convey("The resulting ICMP connection-refused is treated as failure", func() {
+			check := checkers.UDP(addr, 200*time.Millisecond)
+			So(check(), ShouldNotBeNil)
+		})
+	})
+}
+
+func TestGRPCChecker(t *testing.T) {
+	Convey("Given a gRPC server with the health service registered", t, func() {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		So(err, ShouldBeNil)
+		defer ln.Close()
+
+		hs := health.NewServer()
+		hs.SetServingStatus("my-service", grpc_health_v1.HealthCheckResponse_SERVING)
+		hs.SetServingStatus("down-service", grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+
+		srv := grpc.NewServer()
+		grpc_health_v1.RegisterHealthServer(srv, hs)
+		go srv.Serve(ln)
+		defer srv.Stop()
+
+		Convey("GRPC check against a SERVING service passes", func() {
+			check := checkers.GRPC(ln.Addr().String(), "my-service")
+			So(check(), ShouldBeNil)
+		})
+
+		Convey("GRPC check against a NOT_SERVING service fails", func() {
+			check := checkers.GRPC(ln.Addr().String(), "down-service")
+			So(check(), ShouldNotBeNil)
+		})
+
+		Convey("GRPC check against an unregistered service fails", func() {
+			check := checkers.GRPC(ln.Addr().String(), "unknown-service")
+			So(check(), ShouldNotBeNil)
+		})
+	})
+}
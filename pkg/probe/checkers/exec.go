@@ -0,0 +1,79 @@
+package checkers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+
+	"github.com/magneticio/vamp-health-probe/pkg/probe"
+)
+
+// defaultOutputMaxSize bounds how much combined stdout+stderr is captured
+// from a checker process to include in the failure error.
+const defaultOutputMaxSize = 64 * 1024
+
+// Exec returns a probe.HealthStatusChecker that runs cmd with args, passing
+// on exit code 0 and failing on any other exit code or launch error. Up to
+// defaultOutputMaxSize bytes of combined stdout+stderr are included in the
+// error on failure; use ExecWithOutputLimit to change that limit.
+func Exec(cmd string, args ...string) probe.HealthStatusChecker {
+	return execChecker(context.Background(), defaultOutputMaxSize, cmd, args)
+}
+
+// ExecContext is the context-aware variant of Exec: cancelling ctx kills an
+// in-flight process immediately instead of waiting for it to exit on its own.
+func ExecContext(ctx context.Context, cmd string, args ...string) probe.HealthStatusChecker {
+	return execChecker(ctx, defaultOutputMaxSize, cmd, args)
+}
+
+// ExecWithOutputLimit behaves like Exec but caps captured output at maxSize
+// bytes instead of defaultOutputMaxSize.
+func ExecWithOutputLimit(maxSize int, cmd string, args ...string) probe.HealthStatusChecker {
+	return execChecker(context.Background(), maxSize, cmd, args)
+}
+
+// ExecCheck is the probe.RichChecker variant of Exec: unlike ExecContext,
+// which closes over a single ctx at construction time, it's handed the
+// provider's own ctx on every run, so cancelling it (e.g. via Stop) kills an
+// in-flight process immediately instead of waiting for it to exit on its own.
+func ExecCheck(cmd string, args ...string) probe.RichChecker {
+	return func(ctx context.Context) probe.Result {
+		if err := execChecker(ctx, defaultOutputMaxSize, cmd, args)(); err != nil {
+			return probe.Result{Status: probe.Critical, Err: err}
+		}
+		return probe.Result{Status: probe.Passing}
+	}
+}
+
+func execChecker(ctx context.Context, outputMaxSize int, cmd string, args []string) probe.HealthStatusChecker {
+	return func() error {
+		c := exec.CommandContext(ctx, cmd, args...)
+		out := &limitedBuffer{max: outputMaxSize}
+		c.Stdout = out
+		c.Stderr = out
+
+		if err := c.Run(); err != nil {
+			return fmt.Errorf("%s: %w", out.buf.String(), err)
+		}
+		return nil
+	}
+}
+
+// limitedBuffer is a bytes.Buffer that silently drops writes past max bytes
+// instead of growing without bound for a chatty checker script.
+type limitedBuffer struct {
+	buf bytes.Buffer
+	max int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if remaining := b.max - b.buf.Len(); remaining > 0 {
+		if remaining < len(p) {
+			p = p[:remaining]
+		}
+		b.buf.Write(p)
+	}
+	return n, nil
+}
@@ -0,0 +1,71 @@
+package checkers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/magneticio/vamp-health-probe/pkg/probe"
+)
+
+// defaultGRPCTimeout bounds GRPC when no context deadline is supplied by the
+// caller, so a hung dial or RPC can't stall the check indefinitely.
+const defaultGRPCTimeout = 5 * time.Second
+
+// GRPC returns a probe.HealthStatusChecker that dials target and queries the
+// grpc.health.v1 Health service for service, failing unless the server
+// reports SERVING.
+func GRPC(target string, service string) probe.HealthStatusChecker {
+	return func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), defaultGRPCTimeout)
+		defer cancel()
+		return grpcCheck(ctx, target, service)
+	}
+}
+
+// GRPCContext is the context-aware variant of GRPC: ctx bounds both the dial
+// and the RPC, so cancelling it abandons an in-flight check immediately.
+func GRPCContext(ctx context.Context, target string, service string) probe.HealthStatusChecker {
+	return func() error {
+		return grpcCheck(ctx, target, service)
+	}
+}
+
+// GRPCCheck is the probe.RichChecker variant of GRPC: unlike GRPCContext,
+// which closes over a single ctx at construction time, it's handed the
+// provider's own ctx on every run, so cancelling it (e.g. via Stop) abandons
+// an in-flight dial or RPC immediately.
+func GRPCCheck(target string, service string) probe.RichChecker {
+	return func(ctx context.Context) probe.Result {
+		if err := GRPCContext(ctx, target, service)(); err != nil {
+			return probe.Result{Status: probe.Critical, Err: err}
+		}
+		return probe.Result{Status: probe.Passing}
+	}
+}
+
+func grpcCheck(ctx context.Context, target string, service string) error {
+	conn, err := grpc.DialContext(ctx, target,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithBlock(),
+	)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	resp, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{
+		Service: service,
+	})
+	if err != nil {
+		return err
+	}
+	if resp.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+		return fmt.Errorf("grpc health check for %q reported status %v", service, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package checkers
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/magneticio/vamp-health-probe/pkg/probe"
+)
+
+type httpConfig struct {
+	method       string
+	minStatus    int
+	maxStatus    int
+	headers      http.Header
+	tlsConfig    *tls.Config
+	bodyContains string
+}
+
+// HTTPOption configures a checker returned by HTTP or HTTPContext.
+type HTTPOption func(*httpConfig)
+
+// WithMethod sets the HTTP method used for the request, GET by default.
+func WithMethod(method string) HTTPOption {
+	return func(c *httpConfig) {
+		c.method = method
+	}
+}
+
+// WithExpectedStatusRange marks the check as passing when the response
+// status falls within [min, max], inclusive. The default range is 200-399.
+func WithExpectedStatusRange(min, max int) HTTPOption {
+	return func(c *httpConfig) {
+		c.minStatus, c.maxStatus = min, max
+	}
+}
+
+// WithHeader adds a header sent with the request. It may be called more
+// than once to add several headers.
+func WithHeader(key, value string) HTTPOption {
+	return func(c *httpConfig) {
+		c.headers.Add(key, value)
+	}
+}
+
+// WithTLSConfig sets the TLS configuration used when the URL is https.
+func WithTLSConfig(tlsConfig *tls.Config) HTTPOption {
+	return func(c *httpConfig) {
+		c.tlsConfig = tlsConfig
+	}
+}
+
+// WithBodyContains additionally requires substr to appear in the response
+// body for the check to pass.
+func WithBodyContains(substr string) HTTPOption {
+	return func(c *httpConfig) {
+		c.bodyContains = substr
+	}
+}
+
+// HTTP returns a probe.HealthStatusChecker that issues an HTTP request to
+// url and fails unless the response status falls within the expected range
+// (200-399 by default) and, if WithBodyContains was given, the response
+// body contains the expected substring.
+func HTTP(url string, opts ...HTTPOption) probe.HealthStatusChecker {
+	return HTTPContext(context.Background(), url, opts...)
+}
+
+// HTTPContext is the context-aware variant of HTTP: cancelling ctx aborts
+// an in-flight request immediately.
+func HTTPContext(ctx context.Context, url string, opts ...HTTPOption) probe.HealthStatusChecker {
+	cfg := httpConfig{
+		method:    http.MethodGet,
+		minStatus: 200,
+		maxStatus: 399,
+		headers:   make(http.Header),
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	client := &http.Client{}
+	if cfg.tlsConfig != nil {
+		client.Transport = &http.Transport{TLSClientConfig: cfg.tlsConfig}
+	}
+
+	return func() error {
+		req, err := http.NewRequestWithContext(ctx, cfg.method, url, nil)
+		if err != nil {
+			return err
+		}
+		req.Header = cfg.headers
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+
+		if resp.StatusCode < cfg.minStatus || resp.StatusCode > cfg.maxStatus {
+			return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+		}
+		if cfg.bodyContains != "" && !strings.Contains(string(body), cfg.bodyContains) {
+			return fmt.Errorf("response body from %s did not contain %q", url, cfg.bodyContains)
+		}
+		return nil
+	}
+}
+
+// HTTPCheck is the probe.RichChecker variant of HTTP: unlike HTTPContext,
+// which closes over a single ctx at construction time, it's handed the
+// provider's own ctx on every run, so cancelling it (e.g. via Stop) aborts
+// an in-flight request immediately instead of waiting out its timeout.
+func HTTPCheck(url string, opts ...HTTPOption) probe.RichChecker {
+	return func(ctx context.Context) probe.Result {
+		if err := HTTPContext(ctx, url, opts...)(); err != nil {
+			return probe.Result{Status: probe.Critical, Err: err}
+		}
+		return probe.Result{Status: probe.Passing}
+	}
+}
@@ -0,0 +1,46 @@
+package checkers
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/magneticio/vamp-health-probe/pkg/probe"
+)
+
+// TCP returns a probe.HealthStatusChecker that dials addr over TCP and
+// closes the connection immediately, failing if the dial doesn't succeed
+// within timeout.
+func TCP(addr string, timeout time.Duration) probe.HealthStatusChecker {
+	return TCPContext(context.Background(), addr, timeout)
+}
+
+// TCPContext is the context-aware variant of TCP: cancelling ctx abandons an
+// in-flight dial immediately instead of waiting out timeout, so callers can
+// wire it to their own shutdown signal to avoid blocking Stop.
+func TCPContext(ctx context.Context, addr string, timeout time.Duration) probe.HealthStatusChecker {
+	return func() error {
+		dialCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		var d net.Dialer
+		conn, err := d.DialContext(dialCtx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
+
+// TCPCheck is the probe.RichChecker variant of TCP: unlike TCPContext, which
+// closes over a single ctx at construction time, it's handed the provider's
+// own ctx on every run, so cancelling it (e.g. via Stop) abandons an
+// in-flight dial immediately instead of waiting out timeout.
+func TCPCheck(addr string, timeout time.Duration) probe.RichChecker {
+	return func(ctx context.Context) probe.Result {
+		if err := TCPContext(ctx, addr, timeout)(); err != nil {
+			return probe.Result{Status: probe.Critical, Err: err}
+		}
+		return probe.Result{Status: probe.Passing}
+	}
+}
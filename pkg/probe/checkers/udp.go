@@ -0,0 +1,77 @@
+package checkers
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/magneticio/vamp-health-probe/pkg/probe"
+)
+
+// UDP returns a probe.HealthStatusChecker that sends a single-byte datagram
+// to addr and waits for a reply. UDP has no handshake, so an ICMP
+// "connection refused" is treated as a failure while a plain read timeout -
+// nobody objected, but nobody answered either - is treated as success, the
+// same semantics Consul's UDP check uses (hashicorp/consul#12722).
+func UDP(addr string, timeout time.Duration) probe.HealthStatusChecker {
+	return UDPContext(context.Background(), addr, timeout)
+}
+
+// UDPContext is the context-aware variant of UDP: cancelling ctx abandons an
+// in-flight check immediately instead of waiting out timeout.
+func UDPContext(ctx context.Context, addr string, timeout time.Duration) probe.HealthStatusChecker {
+	return func() error {
+		d := net.Dialer{Timeout: timeout}
+		conn, err := d.DialContext(ctx, "udp", addr)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		if deadline, ok := ctx.Deadline(); ok {
+			conn.SetDeadline(deadline)
+		} else {
+			conn.SetDeadline(time.Now().Add(timeout))
+		}
+
+		// Cancelling ctx cuts a pending read short by moving the deadline to now.
+		watchDone := make(chan struct{})
+		defer close(watchDone)
+		go func() {
+			select {
+			case <-ctx.Done():
+				conn.SetDeadline(time.Now())
+			case <-watchDone:
+			}
+		}()
+
+		if _, err := conn.Write([]byte{0}); err != nil {
+			return err
+		}
+
+		buf := make([]byte, 1)
+		if _, err := conn.Read(buf); err != nil {
+			if ctx.Err() == context.Canceled {
+				return ctx.Err()
+			}
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				return nil
+			}
+			return err
+		}
+		return nil
+	}
+}
+
+// UDPCheck is the probe.RichChecker variant of UDP: unlike UDPContext, which
+// closes over a single ctx at construction time, it's handed the provider's
+// own ctx on every run, so cancelling it (e.g. via Stop) abandons an
+// in-flight check immediately instead of waiting out timeout.
+func UDPCheck(addr string, timeout time.Duration) probe.RichChecker {
+	return func(ctx context.Context) probe.Result {
+		if err := UDPContext(ctx, addr, timeout)(); err != nil {
+			return probe.Result{Status: probe.Critical, Err: err}
+		}
+		return probe.Result{Status: probe.Passing}
+	}
+}
@@ -1,22 +1,81 @@
 package probe
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"math/rand"
 	"net/http"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // HealthStatusChecker - single function used to check health status
 type HealthStatusChecker func() error
 
+// Status is the three-state outcome of a health check, ordered from best to
+// worst so that the worst of several checks is simply the maximum.
+type Status int
+
+// Status values a RichChecker can report.
+const (
+	Passing Status = iota
+	Warning
+	Critical
+)
+
+func (s Status) String() string {
+	switch s {
+	case Passing:
+		return "passing"
+	case Warning:
+		return "warning"
+	case Critical:
+		return "critical"
+	default:
+		return "unknown"
+	}
+}
+
+// Result is what a RichChecker reports for a single run.
+type Result struct {
+	Status Status
+	Output string
+	Err    error
+}
+
+// RichChecker is a health check that reports a three-state Result instead of
+// a plain error, and can be cancelled through ctx.
+type RichChecker func(ctx context.Context) Result
+
+// CheckMetrics augments Result with the instrumentation collected during the
+// last run of a check, for exporters such as pkg/probe/metrics.
+type CheckMetrics struct {
+	Result
+	Duration    time.Duration
+	LastSuccess time.Time
+}
+
 // HealthStatusProvider - defines health status provider
 type HealthStatusProvider interface {
 	Handler(w http.ResponseWriter, r *http.Request)
+	LivenessHandler(w http.ResponseWriter, r *http.Request)
+	ReadinessHandler(w http.ResponseWriter, r *http.Request)
+	InstallHandlers(mux *http.ServeMux, prefix string)
 	Collect()
 	Start(time.Duration)
 	Stop(tm time.Duration) error
 	Get() error
+	GetDetailed() map[string]Result
+	GetMetrics() map[string]CheckMetrics
+	Drain(quietPeriod time.Duration) error
+	AddChecker(name string, c HealthStatusChecker) error
+	AddCheck(name string, c RichChecker, opts ...CheckOption) error
+	RemoveChecker(name string)
 }
 
 // HealthStatusProviderOption - option for healthStatus
@@ -36,6 +95,87 @@ func WithLogger(logger Logger) HealthStatusProviderOption {
 	}
 }
 
+// WithDrainUserAgentPrefixes - option letting probes whose User-Agent starts
+// with one of the given prefixes keep receiving the pre-drain readiness
+// result while the provider is draining, mirroring how Knative's drainer
+// short-circuits activator probes during a rolling shutdown.
+func WithDrainUserAgentPrefixes(prefixes []string) HealthStatusProviderOption {
+	return func(provider *healthStatus) {
+		provider.drainUAPrefixes = prefixes
+	}
+}
+
+// WithCheckSchedule overrides the interval, timeout and jitter fraction used
+// to run the named check once Start is called, regardless of how the check
+// was registered (the checkers map, AddChecker or AddCheck). Checks without
+// an explicit schedule fall back to the interval passed to Start and run
+// with no timeout.
+func WithCheckSchedule(name string, interval, timeout time.Duration, jitter float64) HealthStatusProviderOption {
+	return func(provider *healthStatus) {
+		provider.schedules[name] = checkConfig{
+			interval: interval,
+			timeout:  timeout,
+			jitter:   jitter,
+		}
+	}
+}
+
+const (
+	defaultSuccessBeforePassing   = 1
+	defaultFailuresBeforeCritical = 1
+)
+
+type checkConfig struct {
+	timeout                time.Duration
+	interval               time.Duration
+	jitter                 float64
+	successBeforePassing   int
+	failuresBeforeCritical int
+}
+
+func defaultCheckConfig() checkConfig {
+	return checkConfig{
+		successBeforePassing:   defaultSuccessBeforePassing,
+		failuresBeforeCritical: defaultFailuresBeforeCritical,
+	}
+}
+
+// CheckOption configures a check registered with AddCheck.
+type CheckOption func(*checkConfig)
+
+// WithInterval sets how often the check runs.
+func WithInterval(d time.Duration) CheckOption {
+	return func(c *checkConfig) {
+		c.interval = d
+	}
+}
+
+// WithTimeout bounds how long a single run of the check is allowed to take
+// before it's cancelled through the context.Context passed to RichChecker.
+func WithTimeout(d time.Duration) CheckOption {
+	return func(c *checkConfig) {
+		c.timeout = d
+	}
+}
+
+// WithSuccessBeforePassing requires n consecutive passing runs before the
+// check transitions back from Warning/Critical to Passing, so one lucky
+// probe doesn't immediately clear an outage.
+func WithSuccessBeforePassing(n int) CheckOption {
+	return func(c *checkConfig) {
+		c.successBeforePassing = n
+	}
+}
+
+// WithFailuresBeforeCritical requires n consecutive Critical runs before
+// the check transitions to Critical, so a transient blip doesn't flap the
+// aggregate. A Warning run takes effect immediately and resets that count.
+func WithFailuresBeforeCritical(n int) CheckOption {
+	return func(c *checkConfig) {
+		c.failuresBeforeCritical = n
+	}
+}
+
 type stdLogger struct{}
 
 func (logger *stdLogger) Debug(args ...interface{}) {
@@ -49,25 +189,111 @@ func (logger *stdLogger) Error(args ...interface{}) {
 }
 
 type healthStatus struct {
-	checkers map[string]HealthStatusChecker
-	results  map[string]*checkresult
-	stopper  chan struct{}
-	checkwg  *sync.WaitGroup
-	logger   Logger
+	mu        sync.RWMutex
+	checks    map[string]*checkresult
+	schedules map[string]checkConfig
+	stopper   chan struct{}
+	checkwg   *sync.WaitGroup
+	logger    Logger
+
+	// ctx is cancelled by Stop so an in-flight runCheck backed by a
+	// context-aware RichChecker returns immediately instead of blocking
+	// Stop until the check's own timeout elapses.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	started         bool
+	defaultInterval time.Duration
+
+	draining        int32
+	drainUAPrefixes []string
 }
 
+// checkresult holds a registered RichChecker together with the hysteresis
+// state (last raw result, effective status and streak counters) needed to
+// turn a sequence of raw results into Consul-style Passing/Warning/Critical
+// transitions. Its own mutex guards the fields below so a reader always
+// sees the most recently completed run instead of stale data left over
+// from before the last Collect.
 type checkresult struct {
-	last error
-	cur  chan error
+	check  RichChecker
+	config checkConfig
+
+	// stop, closed via stopOnce, tells this check's own schedule goroutine
+	// to return even though the provider as a whole keeps running, so
+	// RemoveChecker actually halts it instead of leaving it ticking in the
+	// background until Stop.
+	stop     chan struct{}
+	stopOnce sync.Once
+
+	mu            sync.RWMutex
+	last          Result
+	effective     Status
+	successStreak int
+	failureStreak int
+	lastDuration  time.Duration
+	lastSuccess   time.Time
+}
+
+// applyRaw folds a newly observed raw Result into the check's hysteresis
+// state, only moving the effective status once the configured streak
+// threshold has been reached.
+func (cr *checkresult) applyRaw(raw Result) {
+	switch raw.Status {
+	case Critical:
+		cr.failureStreak++
+		cr.successStreak = 0
+		if cr.failureStreak >= cr.config.failuresBeforeCritical {
+			cr.effective = Critical
+		}
+	case Warning:
+		cr.failureStreak = 0
+		cr.successStreak = 0
+		cr.effective = Warning
+	default:
+		cr.successStreak++
+		cr.failureStreak = 0
+		if cr.successStreak >= cr.config.successBeforePassing {
+			cr.effective = Passing
+		}
+	}
+	cr.last = raw
+}
+
+// wrapLegacyChecker adapts the old binary HealthStatusChecker to RichChecker,
+// mapping a nil error to Passing and any non-nil error to Critical. The
+// legacy signature has no ctx of its own, so it can't be cancelled directly;
+// instead, a cancelled ctx makes the wrapper report Critical immediately
+// rather than waiting for c to return, which is what lets Stop cancel a slow
+// legacy checker instead of blocking on it.
+func wrapLegacyChecker(c HealthStatusChecker) RichChecker {
+	return func(ctx context.Context) Result {
+		done := make(chan error, 1)
+		go func() {
+			done <- c()
+		}()
+		select {
+		case err := <-done:
+			if err != nil {
+				return Result{Status: Critical, Err: err}
+			}
+			return Result{Status: Passing}
+		case <-ctx.Done():
+			return Result{Status: Critical, Err: ctx.Err()}
+		}
+	}
 }
 
 // NewHealthStatusProvider - returns new HealthStatusProvider
 func NewHealthStatusProvider(checkers map[string]HealthStatusChecker, options ...HealthStatusProviderOption) HealthStatusProvider {
+	ctx, cancel := context.WithCancel(context.Background())
 	s := &healthStatus{
-		checkers: make(map[string]HealthStatusChecker, len(checkers)),
-		results:  make(map[string]*checkresult, len(checkers)),
-		stopper:  make(chan struct{}),
-		checkwg:  &sync.WaitGroup{},
+		checks:    make(map[string]*checkresult, len(checkers)),
+		schedules: make(map[string]checkConfig),
+		stopper:   make(chan struct{}),
+		checkwg:   &sync.WaitGroup{},
+		ctx:       ctx,
+		cancel:    cancel,
 	}
 	for _, option := range options {
 		option(s)
@@ -75,39 +301,122 @@ func NewHealthStatusProvider(checkers map[string]HealthStatusChecker, options ..
 	if s.logger == nil {
 		s.logger = &stdLogger{}
 	}
-	for k, v := range checkers {
-		s.checkers[k] = v
-		s.results[k] = &checkresult{
-			cur: make(chan error),
-		}
+	for name, c := range checkers {
+		s.registerCheck(name, wrapLegacyChecker(c), defaultCheckConfig())
 	}
 	return s
 }
 
+func (s *healthStatus) registerCheck(name string, c RichChecker, cfg checkConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.checks[name]; ok {
+		return fmt.Errorf("check %q already registered", name)
+	}
+	if sched, ok := s.schedules[name]; ok {
+		cfg.interval, cfg.timeout, cfg.jitter = sched.interval, sched.timeout, sched.jitter
+	}
+	cr := &checkresult{
+		check:  c,
+		config: cfg,
+		stop:   make(chan struct{}),
+	}
+	s.checks[name] = cr
+	// A check registered through AddChecker/AddCheck after Start has
+	// already run needs its own schedule started immediately, since Start
+	// only launches a loop for the checks it sees at that instant.
+	if s.started {
+		s.startCheckLoop(name, cr, s.defaultInterval)
+	}
+	return nil
+}
+
+// runCheck executes a single check, bounding it by its configured timeout if
+// any, and records the result, its duration and - on a Passing outcome -
+// the time it last succeeded. ctx is cancelled when Stop is called, so a
+// context-aware check returns immediately instead of running out its
+// timeout.
+func (s *healthStatus) runCheck(name string, cr *checkresult) {
+	ctx := s.ctx
+	if cr.config.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cr.config.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	raw := cr.check(ctx)
+	duration := time.Since(start)
+
+	cr.mu.Lock()
+	cr.applyRaw(raw)
+	cr.lastDuration = duration
+	if raw.Status == Passing {
+		cr.lastSuccess = start
+	}
+	cr.mu.Unlock()
+}
+
 func (s *healthStatus) Collect() {
-	for n, c := range s.checkers {
+	s.mu.RLock()
+	checks := make(map[string]*checkresult, len(s.checks))
+	for name, cr := range s.checks {
+		checks[name] = cr
+	}
+	s.mu.RUnlock()
+
+	for name, cr := range checks {
 		s.checkwg.Add(1)
-		go func(f HealthStatusChecker, ch chan error) {
+		go func(name string, cr *checkresult) {
 			defer s.checkwg.Done()
-			ch <- f()
-		}(c, s.results[n].cur)
+			s.runCheck(name, cr)
+		}(name, cr)
 	}
 }
 
+// snapshot reads the most recently completed result for every registered
+// check - already hysteresis-applied - keyed by check name. Unlike the
+// single global ticker this replaced, there's no channel to drain, so a
+// reader always sees the latest completed run instead of data left stale by
+// a Get that raced ahead of a Collect.
+func (s *healthStatus) snapshot() map[string]Result {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make(map[string]Result, len(s.checks))
+	for name, cr := range s.checks {
+		cr.mu.RLock()
+		out[name] = Result{Status: cr.effective, Output: cr.last.Output, Err: cr.last.Err}
+		cr.mu.RUnlock()
+	}
+	return out
+}
+
+// GetMetrics returns Result plus run instrumentation for every registered
+// check, the data source behind pkg/probe/metrics' Prometheus collector.
+func (s *healthStatus) GetMetrics() map[string]CheckMetrics {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make(map[string]CheckMetrics, len(s.checks))
+	for name, cr := range s.checks {
+		cr.mu.RLock()
+		out[name] = CheckMetrics{
+			Result:      Result{Status: cr.effective, Output: cr.last.Output, Err: cr.last.Err},
+			Duration:    cr.lastDuration,
+			LastSuccess: cr.lastSuccess,
+		}
+		cr.mu.RUnlock()
+	}
+	return out
+}
+
 func (s *healthStatus) Get() error {
-	for n, res := range s.results {
-		var r error
-		for empty := false; !empty; {
-			select {
-			case r = <-res.cur:
-				res.last = r
-			default:
-				empty = true
-				r = res.last
+	for n, r := range s.snapshot() {
+		if r.Status != Passing {
+			cause := r.Err
+			if cause == nil {
+				cause = errors.New(r.Output)
 			}
-		}
-		if r != nil {
-			err := fmt.Errorf("Health check of %v failed: %v", n, r)
+			err := fmt.Errorf("Health check of %v failed: %v", n, cause)
 			s.logger.Error(err)
 			return err
 		}
@@ -115,17 +424,92 @@ func (s *healthStatus) Get() error {
 	return nil
 }
 
+// GetDetailed returns the effective Result for every registered check, so
+// operators can scrape per-check state instead of only the aggregate.
+func (s *healthStatus) GetDetailed() map[string]Result {
+	return s.snapshot()
+}
+
+// AddChecker registers a new binary checker under name so it participates in
+// Collect, Get and the aggregate endpoint installed by InstallHandlers; it
+// gets a per-check endpoint only if it was registered before InstallHandlers
+// was called. Real services
+// often discover dependencies at runtime (leader election, sidecars, etc.)
+// so checks don't all have to be known at NewHealthStatusProvider time.
+func (s *healthStatus) AddChecker(name string, c HealthStatusChecker) error {
+	return s.registerCheck(name, wrapLegacyChecker(c), defaultCheckConfig())
+}
+
+// AddCheck registers a three-state check under name. Without options the
+// check uses no hysteresis (a single failure flips it to Critical and a
+// single success flips it back to Passing); use WithSuccessBeforePassing and
+// WithFailuresBeforeCritical to smooth out a flapping check.
+func (s *healthStatus) AddCheck(name string, c RichChecker, opts ...CheckOption) error {
+	cfg := defaultCheckConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return s.registerCheck(name, c, cfg)
+}
+
+// RemoveChecker unregisters the check with the given name, if any, and - if
+// Start has already launched its schedule goroutine - stops that goroutine
+// too, so a removed check doesn't keep dialing/exec'ing on its own schedule
+// until the provider as a whole is stopped.
+func (s *healthStatus) RemoveChecker(name string) {
+	s.mu.Lock()
+	cr, ok := s.checks[name]
+	delete(s.checks, name)
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+	cr.stopOnce.Do(func() { close(cr.stop) })
+}
+
+// Start launches one goroutine per registered check, each looping on its
+// own interval, timeout and jitter instead of a single shared ticker. A
+// check configured with WithCheckSchedule or WithInterval uses its own
+// schedule; any other check falls back to the default interval d. Checks
+// registered afterwards via AddChecker/AddCheck pick up d as their default
+// too, since registerCheck starts their loop immediately once s.started.
 func (s *healthStatus) Start(d time.Duration) {
-	ticker := time.NewTicker(d)
+	s.mu.Lock()
+	s.started = true
+	s.defaultInterval = d
+	checks := make(map[string]*checkresult, len(s.checks))
+	for name, cr := range s.checks {
+		checks[name] = cr
+	}
+	s.mu.Unlock()
+
+	for name, cr := range checks {
+		s.startCheckLoop(name, cr, d)
+	}
+}
+
+func (s *healthStatus) startCheckLoop(name string, cr *checkresult, defaultInterval time.Duration) {
+	interval := cr.config.interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	s.checkwg.Add(1)
 	go func() {
+		defer s.checkwg.Done()
 		for {
+			wait := interval
+			if cr.config.jitter > 0 {
+				wait += time.Duration(cr.config.jitter * float64(interval) * rand.Float64())
+			}
 			select {
-			case <-ticker.C:
-				s.logger.Debug("Collecting health statuses")
-				s.Get()
-				s.Collect()
+			case <-time.After(wait):
+				s.logger.Debug("Collecting health status for ", name)
+				s.runCheck(name, cr)
 			case <-s.stopper:
 				return
+			case <-cr.stop:
+				return
 			}
 		}
 	}()
@@ -136,6 +520,7 @@ func (s *healthStatus) Start(d time.Duration) {
 // It can be called only once after Start has been called
 func (s *healthStatus) Stop(tm time.Duration) error {
 	close(s.stopper)
+	s.cancel()
 
 	c := make(chan struct{})
 	go func() {
@@ -164,3 +549,169 @@ func (s *healthStatus) Handler(w http.ResponseWriter, r *http.Request) {
 	}
 	w.WriteHeader(http.StatusOK)
 }
+
+// LivenessHandler always returns 200: this package has no notion of a fatal
+// internal error distinct from a failing check, and a failing check should
+// make the pod unready rather than killed outright. Unlike ReadinessHandler
+// it keeps returning 200 while the provider is draining, so Kubernetes
+// doesn't kill the pod before the non-ready state served by ReadinessHandler
+// has had a chance to propagate.
+func (s *healthStatus) LivenessHandler(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+// ReadinessHandler behaves like Handler, except that while the provider is
+// draining it immediately answers with 503 so load balancers stop routing
+// traffic, unless the request's User-Agent matches one of the prefixes
+// passed to WithDrainUserAgentPrefixes, in which case it keeps serving the
+// pre-drain result.
+func (s *healthStatus) ReadinessHandler(w http.ResponseWriter, r *http.Request) {
+	if s.isDraining() && !s.isDrainExempt(r) {
+		http.Error(w, "draining", http.StatusServiceUnavailable)
+		return
+	}
+	s.Handler(w, r)
+}
+
+func (s *healthStatus) isDraining() bool {
+	return atomic.LoadInt32(&s.draining) == 1
+}
+
+func (s *healthStatus) isDrainExempt(r *http.Request) bool {
+	ua := r.UserAgent()
+	for _, prefix := range s.drainUAPrefixes {
+		if strings.HasPrefix(ua, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Drain atomically transitions the provider into "draining": ReadinessHandler
+// starts failing immediately so load balancers stop routing new traffic,
+// while LivenessHandler keeps returning 200 and Collect keeps running for
+// quietPeriod. Call it from a SIGTERM handler, then call Stop once it
+// returns, so Kubernetes has time to propagate the non-ready state before
+// the process actually shuts down.
+func (s *healthStatus) Drain(quietPeriod time.Duration) error {
+	if !atomic.CompareAndSwapInt32(&s.draining, 0, 1) {
+		return fmt.Errorf("already draining")
+	}
+	s.logger.Info("Draining health status provider")
+	time.Sleep(quietPeriod)
+	return nil
+}
+
+// InstallHandlers registers, under prefix, one GET endpoint per checker
+// registered at the time of the call (e.g. prefix+"/etcd") returning just
+// that checker's result; checks added later get no endpoint of their own but
+// are still counted by the aggregate. It also registers prefix
+// itself serving the aggregate. The aggregate supports ?verbose=1 for a
+// per-check breakdown and ?exclude=name (repeatable) to drop named checks
+// from the pass/fail verdict while they keep reporting at their own
+// endpoint. This mirrors the surface of k8s.io/apiserver/pkg/server/healthz.
+func (s *healthStatus) InstallHandlers(mux *http.ServeMux, prefix string) {
+	prefix = strings.TrimSuffix(prefix, "/")
+
+	s.mu.RLock()
+	names := make([]string, 0, len(s.checks))
+	for name := range s.checks {
+		names = append(names, name)
+	}
+	s.mu.RUnlock()
+
+	mux.HandleFunc(prefix, s.aggregateHandler)
+	for _, name := range names {
+		mux.HandleFunc(prefix+"/"+name, s.singleCheckHandler(name))
+	}
+}
+
+// statusCode maps a check Status to the HTTP status the aggregate and
+// per-check handlers report it as: 200 for Passing, 429 for Warning and 503
+// for Critical.
+func statusCode(s Status) int {
+	switch s {
+	case Warning:
+		return http.StatusTooManyRequests
+	case Critical:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusOK
+	}
+}
+
+func formatResultLine(name string, r Result) string {
+	switch r.Status {
+	case Critical:
+		return fmt.Sprintf("[-] %s failed: %v", name, r.Err)
+	case Warning:
+		return fmt.Sprintf("[~] %s warning: %s", name, r.Output)
+	default:
+		return fmt.Sprintf("[+] %s ok", name)
+	}
+}
+
+func (s *healthStatus) singleCheckHandler(name string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		results := s.snapshot()
+		result, ok := results[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		line := formatResultLine(name, result)
+		if code := statusCode(result.Status); code != http.StatusOK {
+			http.Error(w, line, code)
+			return
+		}
+		fmt.Fprint(w, line)
+	}
+}
+
+func (s *healthStatus) aggregateHandler(w http.ResponseWriter, r *http.Request) {
+	excluded := make(map[string]bool)
+	for _, name := range r.URL.Query()["exclude"] {
+		excluded[name] = true
+	}
+
+	results := s.snapshot()
+	worst := Passing
+	for name, result := range results {
+		if excluded[name] {
+			continue
+		}
+		if result.Status > worst {
+			worst = result.Status
+		}
+	}
+	code := statusCode(worst)
+
+	if r.URL.Query().Get("verbose") != "1" {
+		if code != http.StatusOK {
+			http.Error(w, "healthz check failed", code)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	names := make([]string, 0, len(results))
+	for name := range results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	for _, name := range names {
+		buf.WriteString(formatResultLine(name, results[name]))
+		buf.WriteString("\n")
+	}
+
+	if code != http.StatusOK {
+		buf.WriteString("healthz check failed")
+		http.Error(w, buf.String(), code)
+		return
+	}
+	buf.WriteString("healthz check passed")
+	w.Write(buf.Bytes())
+}
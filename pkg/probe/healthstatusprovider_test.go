@@ -1,6 +1,7 @@
 package probe_test
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"net/http/httptest"
@@ -229,31 +230,404 @@ func TestStartStop(t *testing.T) {
 }
 
 func TestStopWithTimeout(t *testing.T) {
-	Convey("Given health status collector", t, func() {
+	Convey("Given a context-aware check that blocks until cancelled", t, func() {
+		var wg sync.WaitGroup
+		s := probe.NewHealthStatusProvider(nil)
+		err := s.AddCheck("first", func(ctx context.Context) probe.Result {
+			wg.Done()
+			<-ctx.Done()
+			return probe.Result{Status: probe.Critical, Err: ctx.Err()}
+		})
+		So(err, ShouldBeNil)
+
+		Convey("When checker func blocks well past Stop's own timeout", func() {
+			Convey("And Start is called", func() {
+				wg.Add(1)
+				s.Start(100 * time.Millisecond)
+
+				Convey("Stop no longer times out, because cancelling ctx unblocks the checker immediately", func() {
+					wg.Wait()
+					err := s.Stop(time.Second)
+					So(err, ShouldBeNil)
+				})
+			})
+		})
+	})
+}
+
+func TestWithCheckSchedule(t *testing.T) {
+	Convey("Given a checker scheduled to run much faster than the Start default", t, func() {
 		var wg sync.WaitGroup
 		cnt := 0
 		s := probe.NewHealthStatusProvider(map[string]probe.HealthStatusChecker{
 			"first": func() error {
-				if cnt == 0 {
+				cnt++
+				if cnt == 3 {
 					wg.Done()
-					cnt = 1
 				}
-				time.Sleep(time.Second)
+				return nil
+			}},
+			probe.WithCheckSchedule("first", 10*time.Millisecond, time.Second, 0),
+		)
+
+		Convey("It ticks on its own schedule rather than the default interval", func() {
+			wg.Add(1)
+			s.Start(time.Hour)
+			wg.Wait()
+			err := s.Stop(time.Second)
+			So(err, ShouldBeNil)
+		})
+	})
+}
+
+func TestGetMetrics(t *testing.T) {
+	Convey("Given a provider with a passing check", t, func() {
+		var wg sync.WaitGroup
+		s := probe.NewHealthStatusProvider(map[string]probe.HealthStatusChecker{
+			"first": func() error {
+				defer wg.Done()
 				return nil
 			}})
+		wg.Add(1)
+		s.Collect()
+		wg.Wait()
+
+		Convey("GetMetrics reports its status and a non-zero last success time", func() {
+			metrics := s.GetMetrics()
+			m, ok := metrics["first"]
+			So(ok, ShouldBeTrue)
+			So(m.Status, ShouldEqual, probe.Passing)
+			So(m.LastSuccess.IsZero(), ShouldBeFalse)
+		})
+	})
+}
 
-		Convey("When checker func takes too long to complete", func() {
-			Convey("And Start is called", func() {
-				wg.Add(1)
-				s.Start(100 * time.Millisecond)
+func TestLivenessReadinessHandlers(t *testing.T) {
+	Convey("Giving health status collector with a failing check", t, func() {
+		var wg sync.WaitGroup
+		s := probe.NewHealthStatusProvider(map[string]probe.HealthStatusChecker{
+			"first": func() error {
+				defer wg.Done()
+				return errors.New("Some error")
+			}})
+		wg.Add(1)
+		s.Collect()
+		wg.Wait()
+
+		Convey("LivenessHandler should still return HTTP status OK", func() {
+			req := httptest.NewRequest("GET", "http://localhost/livez", nil)
+			w := httptest.NewRecorder()
+			s.LivenessHandler(w, req)
+			resp := w.Result()
+			So(resp.StatusCode, ShouldEqual, http.StatusOK)
+		})
 
-				Convey("Stop should timed out", func() {
-					wg.Wait()
-					err := s.Stop(100 * time.Millisecond)
-					So(err, ShouldNotBeNil)
-					So(err.Error(), ShouldContainSubstring, "timeout")
+		Convey("ReadinessHandler should return HTTP error", func() {
+			req := httptest.NewRequest("GET", "http://localhost/readyz", nil)
+			w := httptest.NewRecorder()
+			s.ReadinessHandler(w, req)
+			resp := w.Result()
+			So(resp.StatusCode, ShouldEqual, http.StatusInternalServerError)
+		})
+	})
+}
+
+func TestDrain(t *testing.T) {
+	Convey("Given health status collector with a passing check", t, func() {
+		var wg sync.WaitGroup
+		s := probe.NewHealthStatusProvider(map[string]probe.HealthStatusChecker{
+			"first": func() error {
+				defer wg.Done()
+				return nil
+			}})
+		wg.Add(1)
+		s.Collect()
+		wg.Wait()
+
+		Convey("When Drain is called", func() {
+			done := make(chan error, 1)
+			go func() {
+				done <- s.Drain(50 * time.Millisecond)
+			}()
+
+			Convey("ReadinessHandler immediately starts failing", func() {
+				time.Sleep(10 * time.Millisecond)
+				req := httptest.NewRequest("GET", "http://localhost/readyz", nil)
+				w := httptest.NewRecorder()
+				s.ReadinessHandler(w, req)
+				resp := w.Result()
+				So(resp.StatusCode, ShouldEqual, http.StatusServiceUnavailable)
+
+				Convey("But LivenessHandler keeps returning OK", func() {
+					req := httptest.NewRequest("GET", "http://localhost/livez", nil)
+					w := httptest.NewRecorder()
+					s.LivenessHandler(w, req)
+					resp := w.Result()
+					So(resp.StatusCode, ShouldEqual, http.StatusOK)
 				})
 			})
+
+			Convey("And Drain returns nil once the quiet period elapses", func() {
+				err := <-done
+				So(err, ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestDrainWithUserAgentPrefixes(t *testing.T) {
+	Convey("Given health status collector exempting load balancer probes from draining", t, func() {
+		var wg sync.WaitGroup
+		s := probe.NewHealthStatusProvider(map[string]probe.HealthStatusChecker{
+			"first": func() error {
+				defer wg.Done()
+				return nil
+			}}, probe.WithDrainUserAgentPrefixes([]string{"kube-probe/"}))
+		wg.Add(1)
+		s.Collect()
+		wg.Wait()
+
+		Convey("When the provider is draining", func() {
+			go s.Drain(50 * time.Millisecond)
+			time.Sleep(10 * time.Millisecond)
+
+			Convey("A request from a known load balancer UA still gets the pre-drain result", func() {
+				req := httptest.NewRequest("GET", "http://localhost/readyz", nil)
+				req.Header.Set("User-Agent", "kube-probe/1.27")
+				w := httptest.NewRecorder()
+				s.ReadinessHandler(w, req)
+				resp := w.Result()
+				So(resp.StatusCode, ShouldEqual, http.StatusOK)
+			})
+
+			Convey("A request from any other UA is rejected", func() {
+				req := httptest.NewRequest("GET", "http://localhost/readyz", nil)
+				w := httptest.NewRecorder()
+				s.ReadinessHandler(w, req)
+				resp := w.Result()
+				So(resp.StatusCode, ShouldEqual, http.StatusServiceUnavailable)
+			})
+		})
+	})
+}
+
+func TestAddRemoveChecker(t *testing.T) {
+	Convey("Given an empty health status collector", t, func() {
+		s := probe.NewHealthStatusProvider(nil)
+		var wg sync.WaitGroup
+
+		Convey("AddChecker registers a new check that participates in Get", func() {
+			err := s.AddChecker("first", func() error {
+				defer wg.Done()
+				return errors.New("Some error")
+			})
+			So(err, ShouldBeNil)
+			wg.Add(1)
+			s.Collect()
+			wg.Wait()
+
+			err = s.Get()
+			So(err, ShouldNotBeNil)
+
+			Convey("Adding the same name twice fails", func() {
+				err := s.AddChecker("first", func() error { return nil })
+				So(err, ShouldNotBeNil)
+			})
+
+			Convey("RemoveChecker takes it out of Get again", func() {
+				s.RemoveChecker("first")
+				s.Collect()
+				err := s.Get()
+				So(err, ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestRemoveCheckerStopsSchedule(t *testing.T) {
+	Convey("Given a check running on its own schedule after Start", t, func() {
+		s := probe.NewHealthStatusProvider(nil)
+		var runs int32
+		err := s.AddChecker("ticking", func() error {
+			atomic.AddInt32(&runs, 1)
+			return nil
+		})
+		So(err, ShouldBeNil)
+		s.Start(5 * time.Millisecond)
+		time.Sleep(30 * time.Millisecond)
+
+		Convey("RemoveChecker halts its goroutine instead of leaving it running until Stop", func() {
+			s.RemoveChecker("ticking")
+			after := atomic.LoadInt32(&runs)
+			time.Sleep(30 * time.Millisecond)
+
+			So(atomic.LoadInt32(&runs), ShouldEqual, after)
+			So(s.Stop(time.Second), ShouldBeNil)
+		})
+	})
+}
+
+func TestAddCheckerAfterStart(t *testing.T) {
+	Convey("Given a health status collector already started", t, func() {
+		s := probe.NewHealthStatusProvider(nil)
+		s.Start(10 * time.Millisecond)
+		defer s.Stop(time.Second)
+
+		Convey("AddChecker still gets the new check scheduled and running", func() {
+			var wg sync.WaitGroup
+			var once sync.Once
+			wg.Add(1)
+			err := s.AddChecker("late", func() error {
+				once.Do(wg.Done)
+				return errors.New("boom")
+			})
+			So(err, ShouldBeNil)
+
+			wg.Wait()
+			So(s.Get(), ShouldNotBeNil)
+		})
+	})
+}
+
+func TestAddCheckThreeState(t *testing.T) {
+	Convey("Given an empty health status collector", t, func() {
+		s := probe.NewHealthStatusProvider(nil)
+		var wg sync.WaitGroup
+
+		Convey("A check reporting Warning makes Get fail and GetDetailed show it", func() {
+			err := s.AddCheck("disk", func(ctx context.Context) probe.Result {
+				defer wg.Done()
+				return probe.Result{Status: probe.Warning, Output: "disk at 85%"}
+			})
+			So(err, ShouldBeNil)
+			wg.Add(1)
+			s.Collect()
+			wg.Wait()
+
+			So(s.Get(), ShouldNotBeNil)
+			detail := s.GetDetailed()
+			So(detail["disk"].Status, ShouldEqual, probe.Warning)
+			So(detail["disk"].Output, ShouldEqual, "disk at 85%")
+		})
+	})
+}
+
+func TestAddCheckHysteresis(t *testing.T) {
+	Convey("Given a check requiring 2 failures before Critical and 2 successes before Passing", t, func() {
+		var wg sync.WaitGroup
+		var res probe.Result
+		s := probe.NewHealthStatusProvider(nil)
+		err := s.AddCheck("flaky", func(ctx context.Context) probe.Result {
+			defer wg.Done()
+			return res
+		}, probe.WithFailuresBeforeCritical(2), probe.WithSuccessBeforePassing(2))
+		So(err, ShouldBeNil)
+
+		collect := func() {
+			wg.Add(1)
+			s.Collect()
+			wg.Wait()
+		}
+
+		Convey("A single failure doesn't flip it to Critical yet", func() {
+			res = probe.Result{Status: probe.Critical, Err: errors.New("boom")}
+			collect()
+			So(s.GetDetailed()["flaky"].Status, ShouldEqual, probe.Passing)
+
+			Convey("A second consecutive failure does", func() {
+				collect()
+				So(s.GetDetailed()["flaky"].Status, ShouldEqual, probe.Critical)
+
+				Convey("One success isn't enough to clear it", func() {
+					res = probe.Result{Status: probe.Passing}
+					collect()
+					So(s.GetDetailed()["flaky"].Status, ShouldEqual, probe.Critical)
+
+					Convey("But a second consecutive success is", func() {
+						collect()
+						So(s.GetDetailed()["flaky"].Status, ShouldEqual, probe.Passing)
+					})
+				})
+			})
+		})
+	})
+}
+
+func TestInstallHandlers(t *testing.T) {
+	Convey("Given a health status collector with two checks", t, func() {
+		var wg sync.WaitGroup
+		var res1, res2 error
+		s := probe.NewHealthStatusProvider(map[string]probe.HealthStatusChecker{
+			"etcd": func() error {
+				defer wg.Done()
+				return res1
+			},
+			"db": func() error {
+				defer wg.Done()
+				return res2
+			},
+		})
+
+		mux := http.NewServeMux()
+		s.InstallHandlers(mux, "/healthz")
+
+		Convey("When both checks pass", func() {
+			res1, res2 = nil, nil
+			wg.Add(2)
+			s.Collect()
+			wg.Wait()
+
+			Convey("The aggregate endpoint returns 200", func() {
+				req := httptest.NewRequest("GET", "http://localhost/healthz", nil)
+				w := httptest.NewRecorder()
+				mux.ServeHTTP(w, req)
+				So(w.Result().StatusCode, ShouldEqual, http.StatusOK)
+			})
+
+			Convey("Each named endpoint returns its own result", func() {
+				req := httptest.NewRequest("GET", "http://localhost/healthz/etcd", nil)
+				w := httptest.NewRecorder()
+				mux.ServeHTTP(w, req)
+				So(w.Result().StatusCode, ShouldEqual, http.StatusOK)
+			})
+		})
+
+		Convey("When db fails", func() {
+			res1, res2 = nil, errors.New("connection refused")
+			wg.Add(2)
+			s.Collect()
+			wg.Wait()
+
+			Convey("The aggregate endpoint fails", func() {
+				req := httptest.NewRequest("GET", "http://localhost/healthz", nil)
+				w := httptest.NewRecorder()
+				mux.ServeHTTP(w, req)
+				So(w.Result().StatusCode, ShouldEqual, http.StatusServiceUnavailable)
+			})
+
+			Convey("The named db endpoint fails", func() {
+				req := httptest.NewRequest("GET", "http://localhost/healthz/db", nil)
+				w := httptest.NewRecorder()
+				mux.ServeHTTP(w, req)
+				So(w.Result().StatusCode, ShouldEqual, http.StatusServiceUnavailable)
+			})
+
+			Convey("?exclude=db drops it from the aggregate verdict", func() {
+				req := httptest.NewRequest("GET", "http://localhost/healthz?exclude=db", nil)
+				w := httptest.NewRecorder()
+				mux.ServeHTTP(w, req)
+				So(w.Result().StatusCode, ShouldEqual, http.StatusOK)
+			})
+
+			Convey("?verbose=1 lists each check on its own line", func() {
+				req := httptest.NewRequest("GET", "http://localhost/healthz?verbose=1", nil)
+				w := httptest.NewRecorder()
+				mux.ServeHTTP(w, req)
+				body := w.Body.String()
+				So(body, ShouldContainSubstring, "[+] etcd ok")
+				So(body, ShouldContainSubstring, "[-] db failed: connection refused")
+				So(body, ShouldContainSubstring, "healthz check failed")
+			})
 		})
 	})
 }
@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/magneticio/vamp-health-probe/pkg/probe"
+)
+
+var (
+	statusDesc = prometheus.NewDesc(
+		"healthcheck_status",
+		"Current status of a health check (0=passing, 1=warning, 2=critical).",
+		[]string{"name"}, nil,
+	)
+	durationDesc = prometheus.NewDesc(
+		"healthcheck_duration_seconds",
+		"How long the last run of a health check took.",
+		[]string{"name"}, nil,
+	)
+	lastSuccessDesc = prometheus.NewDesc(
+		"healthcheck_last_success_timestamp_seconds",
+		"Unix timestamp of the last passing run of a health check.",
+		[]string{"name"}, nil,
+	)
+)
+
+// Collector implements prometheus.Collector over a probe.HealthStatusProvider,
+// exposing healthcheck_status, healthcheck_duration_seconds and
+// healthcheck_last_success_timestamp_seconds gauges for every registered
+// check, the same shape k8s healthz exposes via component-base's
+// prometheus/sli package.
+type Collector struct {
+	provider probe.HealthStatusProvider
+}
+
+// NewCollector wraps provider as a prometheus.Collector ready to be passed
+// to prometheus.Register or a custom Registry.
+func NewCollector(provider probe.HealthStatusProvider) *Collector {
+	return &Collector{provider: provider}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- statusDesc
+	ch <- durationDesc
+	ch <- lastSuccessDesc
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	for name, m := range c.provider.GetMetrics() {
+		ch <- prometheus.MustNewConstMetric(statusDesc, prometheus.GaugeValue, float64(m.Status), name)
+		ch <- prometheus.MustNewConstMetric(durationDesc, prometheus.GaugeValue, m.Duration.Seconds(), name)
+		if !m.LastSuccess.IsZero() {
+			ch <- prometheus.MustNewConstMetric(lastSuccessDesc, prometheus.GaugeValue, float64(m.LastSuccess.Unix()), name)
+		}
+	}
+}
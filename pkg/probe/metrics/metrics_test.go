@@ -0,0 +1,50 @@
+package metrics_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/magneticio/vamp-health-probe/pkg/probe"
+	"github.com/magneticio/vamp-health-probe/pkg/probe/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCollector(t *testing.T) {
+	Convey("Given a provider with one passing check", t, func() {
+		var wg sync.WaitGroup
+		s := probe.NewHealthStatusProvider(nil)
+		err := s.AddCheck("db", func(ctx context.Context) probe.Result {
+			defer wg.Done()
+			return probe.Result{Status: probe.Passing}
+		})
+		So(err, ShouldBeNil)
+		wg.Add(1)
+		s.Collect()
+		wg.Wait()
+
+		Convey("Collecting yields the documented gauges for that check", func() {
+			c := metrics.NewCollector(s)
+			ch := make(chan prometheus.Metric, 10)
+			c.Collect(ch)
+			close(ch)
+
+			var statusValue float64
+			count := 0
+			for m := range ch {
+				count++
+				var pb dto.Metric
+				So(m.Write(&pb), ShouldBeNil)
+				if strings.Contains(m.Desc().String(), "healthcheck_status") {
+					statusValue = pb.GetGauge().GetValue()
+				}
+			}
+			So(count, ShouldBeGreaterThanOrEqualTo, 2)
+			So(statusValue, ShouldEqual, float64(probe.Passing))
+		})
+	})
+}